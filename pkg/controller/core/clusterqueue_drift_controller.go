@@ -0,0 +1,121 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kueue "sigs.k8s.io/kueue/api/v1alpha1"
+	"sigs.k8s.io/kueue/pkg/queue"
+	"sigs.k8s.io/kueue/pkg/workload"
+)
+
+// ClusterQueueDriftReconciler re-evaluates admitted workloads whenever their
+// ClusterQueue's resources, flavors or quotas change, and evicts the ones
+// that no longer fit.
+type ClusterQueueDriftReconciler struct {
+	client.Client
+	queues *queue.Manager
+	log    logr.Logger
+}
+
+func NewClusterQueueDriftReconciler(client client.Client, queues *queue.Manager, log logr.Logger) *ClusterQueueDriftReconciler {
+	return &ClusterQueueDriftReconciler{
+		Client: client,
+		queues: queues,
+		log:    log.WithName("clusterqueue-drift-controller"),
+	}
+}
+
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=clusterqueues,verbs=get;list;watch
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=queuedworkloads,verbs=get;list;watch;update
+
+func (r *ClusterQueueDriftReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.log.WithValues("clusterQueue", req.Name)
+	var cq kueue.ClusterQueue
+	if err := r.Get(ctx, req.NamespacedName, &cq); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if cq.Spec.DriftPolicy == kueue.DriftPolicyIgnore {
+		return ctrl.Result{}, nil
+	}
+
+	var workloads kueue.QueuedWorkloadList
+	if err := r.List(ctx, &workloads); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var admitted []*workload.Info
+	for i := range workloads.Items {
+		w := &workloads.Items[i]
+		if w.Spec.ClusterQueue != kueue.ClusterQueueReference(cq.Name) || w.Spec.Admission == nil {
+			continue
+		}
+		admitted = append(admitted, workload.NewInfo(w))
+	}
+	usage := workload.AggregateFlavorUsage(admitted)
+
+	for _, info := range admitted {
+		if !info.Drifted(&cq, usage) {
+			continue
+		}
+		log.V(2).Info("Evicting drifted workload", "workload", klogRef(info.Obj))
+		if err := r.evict(ctx, info.Obj); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *ClusterQueueDriftReconciler) evict(ctx context.Context, w *kueue.QueuedWorkload) error {
+	w.Spec.Admission = nil
+	if err := r.Update(ctx, w); err != nil {
+		if apierrors.IsConflict(err) {
+			return nil
+		}
+		return err
+	}
+	w.Status.Conditions = append(w.Status.Conditions, kueue.QueuedWorkloadCondition{
+		Type:               kueue.QueuedWorkloadDrifted,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "FlavorsNoLongerValid",
+		Message:            "ClusterQueue no longer has enough quota for the flavors this workload was admitted with",
+	})
+	if err := r.Status().Update(ctx, w); err != nil {
+		return err
+	}
+	r.queues.RequeueAfterEviction(w)
+	return nil
+}
+
+func klogRef(w *kueue.QueuedWorkload) string {
+	return w.Namespace + "/" + w.Name
+}
+
+func (r *ClusterQueueDriftReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&kueue.ClusterQueue{}).
+		Complete(r)
+}
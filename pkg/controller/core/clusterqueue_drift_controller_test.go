@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kueue "sigs.k8s.io/kueue/api/v1alpha1"
+	"sigs.k8s.io/kueue/pkg/queue"
+	"sigs.k8s.io/kueue/pkg/workload"
+)
+
+func TestClusterQueueDriftReconcilerEvictsAndRequeues(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() error = %v", err)
+	}
+	if err := kueue.AddToScheme(scheme); err != nil {
+		t.Fatalf("kueue AddToScheme() error = %v", err)
+	}
+
+	cq := &kueue.ClusterQueue{
+		ObjectMeta: metav1.ObjectMeta{Name: "cq"},
+		Spec: kueue.ClusterQueueSpec{
+			QueueingStrategy: kueue.StrictFIFO,
+			Resources: []kueue.Resource{{
+				Name: corev1.ResourceCPU,
+				Flavors: []kueue.Flavor{{
+					Name:    "default",
+					Ceiling: resource.MustParse("1"),
+				}},
+			}},
+		},
+	}
+	w := &kueue.QueuedWorkload{
+		ObjectMeta: metav1.ObjectMeta{Name: "w", Namespace: "default"},
+		Spec: kueue.QueuedWorkloadSpec{
+			ClusterQueue: "cq",
+			PodSets: []kueue.PodSet{{
+				Name:  "main",
+				Count: 1,
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+						},
+					}},
+				},
+			}},
+			Admission: &kueue.Admission{
+				ClusterQueue: "cq",
+				PodSetFlavors: []kueue.PodSetFlavors{{
+					Name:    "main",
+					Flavors: map[corev1.ResourceName]string{corev1.ResourceCPU: "default"},
+				}},
+			},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cq, w).Build()
+	queues := queue.NewManager()
+	if err := queues.AddClusterQueue(cq); err != nil {
+		t.Fatalf("AddClusterQueue() error = %v", err)
+	}
+	cqImpl, ok := queues.ClusterQueue("cq")
+	if !ok {
+		t.Fatalf("ClusterQueue(%q) not tracked by manager", "cq")
+	}
+	// w must be recorded as admitted for the reconciler's eviction to exercise
+	// the same admitted-tracking bookkeeping a real admitted workload would,
+	// i.e. that evicting it actually releases it from cq's admitted usage.
+	cqImpl.AddAdmitted(workload.NewInfo(w))
+
+	r := NewClusterQueueDriftReconciler(fakeClient, queues, testr.New(t))
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cq)}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	var got kueue.QueuedWorkload
+	if err := fakeClient.Get(context.Background(), client.ObjectKeyFromObject(w), &got); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Spec.Admission != nil {
+		t.Errorf("Admission = %v, want nil after eviction", got.Spec.Admission)
+	}
+
+	if n := cqImpl.Snapshot(); len(n) != 1 || n[0].Obj.Name != "w" {
+		t.Errorf("Snapshot() = %v, want [w] requeued after drift eviction", n)
+	}
+	if admitted := cqImpl.Admitted(); len(admitted) != 0 {
+		t.Errorf("Admitted() = %v, want none: evicted workload must be released from admitted tracking", admitted)
+	}
+}
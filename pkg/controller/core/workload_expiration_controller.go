@@ -0,0 +1,105 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kueue "sigs.k8s.io/kueue/api/v1alpha1"
+	"sigs.k8s.io/kueue/pkg/queue"
+	"sigs.k8s.io/kueue/pkg/workload"
+)
+
+// WorkloadExpirationReconciler evicts admitted workloads that have run past
+// their Spec.ActiveDeadlineSeconds or their ClusterQueue's MaxRunDuration,
+// whichever comes first.
+type WorkloadExpirationReconciler struct {
+	client.Client
+	queues *queue.Manager
+	log    logr.Logger
+}
+
+func NewWorkloadExpirationReconciler(client client.Client, queues *queue.Manager, log logr.Logger) *WorkloadExpirationReconciler {
+	return &WorkloadExpirationReconciler{
+		Client: client,
+		queues: queues,
+		log:    log.WithName("workload-expiration-controller"),
+	}
+}
+
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=queuedworkloads,verbs=get;list;watch;update
+//+kubebuilder:rbac:groups=kueue.x-k8s.io,resources=clusterqueues,verbs=get;list;watch
+
+func (r *WorkloadExpirationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.log.WithValues("workload", req.NamespacedName)
+	var w kueue.QueuedWorkload
+	if err := r.Get(ctx, req.NamespacedName, &w); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if w.Spec.Admission == nil {
+		return ctrl.Result{}, nil
+	}
+
+	var cq kueue.ClusterQueue
+	if err := r.Get(ctx, client.ObjectKey{Name: string(w.Spec.ClusterQueue)}, &cq); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	info := workload.NewInfo(&w)
+	deadline, ok := info.DeadlineAt(&cq)
+	if !ok {
+		return ctrl.Result{}, nil
+	}
+	if until := time.Until(deadline.Time); until > 0 {
+		return ctrl.Result{RequeueAfter: until}, nil
+	}
+
+	log.V(2).Info("Workload exceeded its runtime, evicting", "deadline", deadline)
+	return ctrl.Result{}, r.expire(ctx, &w)
+}
+
+func (r *WorkloadExpirationReconciler) expire(ctx context.Context, w *kueue.QueuedWorkload) error {
+	w.Spec.Admission = nil
+	if err := r.Update(ctx, w); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	w.Status.Conditions = append(w.Status.Conditions, kueue.QueuedWorkloadCondition{
+		Type:               kueue.QueuedWorkloadExpired,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "DeadlineExceeded",
+		Message:            "Workload ran past its ActiveDeadlineSeconds or ClusterQueue's MaxRunDuration",
+	})
+	if err := r.Status().Update(ctx, w); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	r.queues.RequeueAfterEviction(w)
+	return nil
+}
+
+func (r *WorkloadExpirationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&kueue.QueuedWorkload{}).
+		Complete(r)
+}
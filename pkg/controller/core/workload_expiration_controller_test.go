@@ -0,0 +1,132 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kueue "sigs.k8s.io/kueue/api/v1alpha1"
+	"sigs.k8s.io/kueue/pkg/queue"
+	"sigs.k8s.io/kueue/pkg/workload"
+)
+
+func TestWorkloadExpirationReconciler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() error = %v", err)
+	}
+	if err := kueue.AddToScheme(scheme); err != nil {
+		t.Fatalf("kueue AddToScheme() error = %v", err)
+	}
+
+	cases := map[string]struct {
+		activeDeadlineSeconds *int64
+		admittedSecondsAgo    int64
+		wantExpired           bool
+		wantRequeueAfter      bool
+	}{
+		"past its ActiveDeadlineSeconds: expired": {
+			activeDeadlineSeconds: ptrInt64(60),
+			admittedSecondsAgo:    120,
+			wantExpired:           true,
+		},
+		"within its ActiveDeadlineSeconds: requeued for later": {
+			activeDeadlineSeconds: ptrInt64(3600),
+			admittedSecondsAgo:    60,
+			wantRequeueAfter:      true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cq := &kueue.ClusterQueue{
+				ObjectMeta: metav1.ObjectMeta{Name: "cq"},
+				Spec:       kueue.ClusterQueueSpec{QueueingStrategy: kueue.StrictFIFO},
+			}
+			w := &kueue.QueuedWorkload{
+				ObjectMeta: metav1.ObjectMeta{Name: "w", Namespace: "default"},
+				Spec: kueue.QueuedWorkloadSpec{
+					ClusterQueue:          "cq",
+					ActiveDeadlineSeconds: tc.activeDeadlineSeconds,
+					Admission:             &kueue.Admission{ClusterQueue: "cq"},
+				},
+				Status: kueue.QueuedWorkloadStatus{
+					Conditions: []kueue.QueuedWorkloadCondition{{
+						Type:               kueue.QueuedWorkloadAdmitted,
+						Status:             corev1.ConditionTrue,
+						LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Duration(tc.admittedSecondsAgo) * time.Second)),
+					}},
+				},
+			}
+
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cq, w).Build()
+			queues := queue.NewManager()
+			if err := queues.AddClusterQueue(cq); err != nil {
+				t.Fatalf("AddClusterQueue() error = %v", err)
+			}
+			cqImpl, ok := queues.ClusterQueue("cq")
+			if !ok {
+				t.Fatalf("ClusterQueue(%q) not tracked by manager", "cq")
+			}
+			// w must be recorded as admitted so that, once it expires, we can
+			// check the reconciler actually releases it from cq's admitted
+			// tracking instead of leaving it double-counted.
+			cqImpl.AddAdmitted(workload.NewInfo(w))
+
+			r := NewWorkloadExpirationReconciler(fakeClient, queues, testr.New(t))
+			res, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(w)})
+			if err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+			if tc.wantRequeueAfter && res.RequeueAfter <= 0 {
+				t.Errorf("RequeueAfter = %v, want > 0", res.RequeueAfter)
+			}
+
+			var got kueue.QueuedWorkload
+			if err := fakeClient.Get(context.Background(), client.ObjectKeyFromObject(w), &got); err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if gotExpired := got.Spec.Admission == nil; gotExpired != tc.wantExpired {
+				t.Errorf("evicted = %v, want %v", gotExpired, tc.wantExpired)
+			}
+			if !tc.wantExpired {
+				return
+			}
+			if n := cqImpl.Snapshot(); len(n) != 1 || n[0].Obj.Name != "w" {
+				t.Errorf("Snapshot() = %v, want [w] requeued after expiration", n)
+			}
+			if admitted := cqImpl.Admitted(); len(admitted) != 0 {
+				t.Errorf("Admitted() = %v, want none: expired workload must be released from admitted tracking", admitted)
+			}
+		})
+	}
+}
+
+func ptrInt64(v int64) *int64 {
+	return &v
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"sync"
+
+	kueue "sigs.k8s.io/kueue/api/v1alpha1"
+)
+
+// Manager keeps track of the ClusterQueues known to the scheduler, keyed by
+// name, so that controllers outside this package can requeue a workload
+// without reaching into scheduler-internal state themselves.
+type Manager struct {
+	sync.RWMutex
+
+	clusterQueues map[string]*ClusterQueue
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		clusterQueues: make(map[string]*ClusterQueue),
+	}
+}
+
+func (m *Manager) AddClusterQueue(cq *kueue.ClusterQueue) error {
+	impl, err := newClusterQueue(cq)
+	if err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+	m.clusterQueues[cq.Name] = impl
+	return nil
+}
+
+func (m *Manager) UpdateClusterQueue(cq *kueue.ClusterQueue) {
+	m.Lock()
+	defer m.Unlock()
+	if impl, ok := m.clusterQueues[cq.Name]; ok {
+		impl.update(cq)
+	}
+}
+
+func (m *Manager) DeleteClusterQueue(cq *kueue.ClusterQueue) {
+	m.Lock()
+	defer m.Unlock()
+	delete(m.clusterQueues, cq.Name)
+}
+
+// ClusterQueue returns the named ClusterQueue, or false if it isn't known to
+// the manager.
+func (m *Manager) ClusterQueue(name string) (*ClusterQueue, bool) {
+	m.RLock()
+	defer m.RUnlock()
+	cq, ok := m.clusterQueues[name]
+	return cq, ok
+}
+
+// PushOrUpdate requeues w into the ClusterQueue it points to. It's a no-op if
+// the ClusterQueue isn't tracked by the manager.
+//
+// It takes the exclusive lock, not RLock: cq.PushOrUpdate mutates the
+// ClusterQueue's heap and admitted map, which aren't safe for concurrent
+// access on their own, and RLock would let two callers race on the same
+// ClusterQueue.
+func (m *Manager) PushOrUpdate(w *kueue.QueuedWorkload) {
+	m.Lock()
+	defer m.Unlock()
+	cq, ok := m.clusterQueues[string(w.Spec.ClusterQueue)]
+	if !ok {
+		return
+	}
+	cq.PushOrUpdate(w)
+}
+
+// RequeueAfterEviction forgets w as admitted by its ClusterQueue and pushes
+// it back onto that ClusterQueue's pending heap. Controllers that clear a
+// workload's Admission (drift, expiration) must call this instead of
+// PushOrUpdate alone, or the workload stays counted in the ClusterQueue's
+// admitted usage forever, as if it were still running. It's a no-op if the
+// ClusterQueue isn't tracked by the manager.
+func (m *Manager) RequeueAfterEviction(w *kueue.QueuedWorkload) {
+	m.Lock()
+	defer m.Unlock()
+	cq, ok := m.clusterQueues[string(w.Spec.ClusterQueue)]
+	if !ok {
+		return
+	}
+	cq.DeleteAdmitted(w)
+	cq.PushOrUpdate(w)
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"container/heap"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kueue "sigs.k8s.io/kueue/api/v1alpha1"
+	utilpriority "sigs.k8s.io/kueue/pkg/util/priority"
+	"sigs.k8s.io/kueue/pkg/workload"
+)
+
+// AddAdmitted records w as admitted by cq, so it becomes a preemption
+// candidate for future, higher priority workloads.
+func (cq *ClusterQueue) AddAdmitted(w *workload.Info) {
+	cq.admitted[workload.Key(w.Obj)] = w
+}
+
+// DeleteAdmitted forgets w, e.g. after it finishes or is evicted.
+func (cq *ClusterQueue) DeleteAdmitted(w *kueue.QueuedWorkload) {
+	delete(cq.admitted, workload.Key(w))
+}
+
+// Admitted returns the workloads cq currently considers admitted, i.e. the
+// ones counted towards usage() and eligible as preemption victims.
+func (cq *ClusterQueue) Admitted() []*workload.Info {
+	infos := make([]*workload.Info, 0, len(cq.admitted))
+	for _, info := range cq.admitted {
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// PopWithPreemption behaves like Pop, except that when the popped workload
+// doesn't fit the quota still available in cq, it additionally looks for a
+// set of admitted, lower priority workloads (victims) whose eviction would
+// free enough quota for it. Whether preemption is attempted at all is
+// governed by cq.Spec.PreemptionPolicy.
+//
+// It returns the popped workload and, if non-empty, the victims that the
+// caller must evict to admit it. If the workload doesn't fit and no
+// sufficient set of victims exists, the workload is pushed back onto the
+// heap and PopWithPreemption returns (nil, nil).
+func (cq *ClusterQueue) PopWithPreemption() (*workload.Info, []*workload.Info) {
+	w := cq.Pop()
+	if w == nil {
+		return nil, nil
+	}
+	if cq.fits(w) {
+		return w, nil
+	}
+	if cq.preemptionPolicy == kueue.PreemptionNever {
+		heap.Push(&cq.heap, *w)
+		return nil, nil
+	}
+	victims := cq.preemptionCandidates(w)
+	if victims == nil {
+		heap.Push(&cq.heap, *w)
+		return nil, nil
+	}
+	return w, victims
+}
+
+// fits reports whether w's total request is covered by the quota that isn't
+// currently used by admitted workloads.
+//
+// It doesn't check workload.TopologyConsistent: that needs the flavors a
+// workload is about to be assigned, which aren't known until the scheduler
+// picks them, after fits has already run. This tree has no such scheduler,
+// so there's currently no call site where the check would do anything; it
+// belongs wherever flavor assignment is decided, not here.
+func (cq *ClusterQueue) fits(w *workload.Info) bool {
+	used := cq.usage()
+	for name, val := range totalOf(w) {
+		if used[name]+val > cq.capacity[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// preemptionCandidates walks the admitted workloads that cq.preempts allows w
+// to preempt (strictly lower priority, or also equal priority under
+// PreemptionLowerOrEqualPriority) in ascending (priority, admissionTime)
+// order, and collects them until their release covers w's unmet request.
+// Workloads that release none of the still missing resources are skipped.
+// It returns nil if no such set exists.
+func (cq *ClusterQueue) preemptionCandidates(w *workload.Info) []*workload.Info {
+	priority := utilpriority.Priority(w.Obj)
+	used := cq.usage()
+	deficit := workload.Requests{}
+	for name, val := range totalOf(w) {
+		if d := val - (cq.capacity[name] - used[name]); d > 0 {
+			deficit[name] = d
+		}
+	}
+	if len(deficit) == 0 {
+		return nil
+	}
+
+	candidates := make([]*workload.Info, 0, len(cq.admitted))
+	for _, info := range cq.admitted {
+		if cq.preempts(utilpriority.Priority(info.Obj), priority) {
+			candidates = append(candidates, info)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		pi := utilpriority.Priority(candidates[i].Obj)
+		pj := utilpriority.Priority(candidates[j].Obj)
+		if pi != pj {
+			return pi < pj
+		}
+		return admissionTime(candidates[i]).Before(admissionTime(candidates[j]))
+	})
+
+	var victims []*workload.Info
+	for _, c := range candidates {
+		released := totalOf(c)
+		helps := false
+		for name := range deficit {
+			if released[name] > 0 {
+				helps = true
+				break
+			}
+		}
+		if !helps {
+			continue
+		}
+		victims = append(victims, c)
+		for name, val := range released {
+			if d, ok := deficit[name]; ok {
+				if val >= d {
+					delete(deficit, name)
+				} else {
+					deficit[name] = d - val
+				}
+			}
+		}
+		if len(deficit) == 0 {
+			break
+		}
+	}
+	if len(deficit) > 0 {
+		return nil
+	}
+	return victims
+}
+
+// preempts reports whether an admitted workload at candidatePriority is
+// eligible to be preempted by a pending workload at pendingPriority, given
+// cq's PreemptionPolicy.
+func (cq *ClusterQueue) preempts(candidatePriority, pendingPriority int32) bool {
+	switch cq.preemptionPolicy {
+	case kueue.PreemptionLowerOrEqualPriority:
+		return candidatePriority <= pendingPriority
+	default:
+		// kueue.PreemptionLowerPriority, and the default for ClusterQueues
+		// that haven't set a policy: only strictly lower priority workloads
+		// are candidates, so equal priority siblings never preempt each
+		// other.
+		return candidatePriority < pendingPriority
+	}
+}
+
+// usage adds up the total request of every admitted workload in cq.
+func (cq *ClusterQueue) usage() workload.Requests {
+	u := workload.Requests{}
+	for _, info := range cq.admitted {
+		for name, val := range totalOf(info) {
+			u[name] += val
+		}
+	}
+	return u
+}
+
+// totalOf flattens a workload's per-podSet requests into a single Requests
+// map, ignoring flavor assignment.
+func totalOf(info *workload.Info) workload.Requests {
+	sum := workload.Requests{}
+	for _, ps := range info.TotalRequests {
+		for name, val := range ps.Requests {
+			sum[name] += val
+		}
+	}
+	return sum
+}
+
+// admissionTime returns when info was admitted, falling back to its
+// creation time for workloads admitted before the QueuedWorkloadAdmitted
+// condition was introduced.
+func admissionTime(info *workload.Info) metav1.Time {
+	if t, ok := info.AdmittedAt(); ok {
+		return t
+	}
+	return info.Obj.CreationTimestamp
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kueue "sigs.k8s.io/kueue/api/v1alpha1"
+	"sigs.k8s.io/kueue/pkg/workload"
+)
+
+func admittedWorkload(name string, priority int32, cpu string) *workload.Info {
+	w := &kueue.QueuedWorkload{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: kueue.QueuedWorkloadSpec{
+			ClusterQueue: "cq",
+			PodSets: []kueue.PodSet{{
+				Name:  "main",
+				Count: 1,
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+						},
+					}},
+				},
+			}},
+			Priority: &priority,
+			Admission: &kueue.Admission{
+				ClusterQueue: "cq",
+			},
+		},
+		Status: kueue.QueuedWorkloadStatus{
+			Conditions: []kueue.QueuedWorkloadCondition{{
+				Type:               kueue.QueuedWorkloadAdmitted,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(metav1.Now().Add(0)),
+			}},
+		},
+	}
+	return workload.NewInfo(w)
+}
+
+func pendingWorkload(name string, priority int32, cpu string) *workload.Info {
+	w := admittedWorkload(name, priority, cpu)
+	w.Obj.Spec.Admission = nil
+	w.Obj.Status.Conditions = nil
+	return w
+}
+
+func newTestClusterQueue(cpuCeiling string, policy ...kueue.PreemptionPolicy) *ClusterQueue {
+	p := kueue.PreemptionLowerPriority
+	if len(policy) > 0 {
+		p = policy[0]
+	}
+	cq, err := newClusterQueue(&kueue.ClusterQueue{
+		ObjectMeta: metav1.ObjectMeta{Name: "cq"},
+		Spec: kueue.ClusterQueueSpec{
+			QueueingStrategy: kueue.StrictFIFO,
+			PreemptionPolicy: p,
+			Resources: []kueue.Resource{{
+				Name: corev1.ResourceCPU,
+				Flavors: []kueue.Flavor{{
+					Name:    "default",
+					Ceiling: resource.MustParse(cpuCeiling),
+				}},
+			}},
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return cq
+}
+
+func TestPopWithPreemption(t *testing.T) {
+	cases := map[string]struct {
+		ceiling     string
+		policy      kueue.PreemptionPolicy
+		admitted    []*workload.Info
+		pending     *workload.Info
+		wantPop     bool
+		wantVictims []string
+	}{
+		"fits without preemption": {
+			ceiling:     "4",
+			admitted:    nil,
+			pending:     pendingWorkload("high", 10, "2"),
+			wantPop:     true,
+			wantVictims: nil,
+		},
+		"preempts a single lower priority victim": {
+			ceiling: "2",
+			admitted: []*workload.Info{
+				admittedWorkload("low", 1, "2"),
+			},
+			pending:     pendingWorkload("high", 10, "2"),
+			wantPop:     true,
+			wantVictims: []string{"low"},
+		},
+		"breaks ties by admission time": {
+			ceiling: "4",
+			admitted: []*workload.Info{
+				admittedWorkloadAt("older", 1, "2", 2),
+				admittedWorkloadAt("newer", 1, "2", 1),
+			},
+			pending:     pendingWorkload("high", 10, "2"),
+			wantPop:     true,
+			wantVictims: []string{"older"},
+		},
+		"insufficient candidates, nothing is popped": {
+			// Evicting every eligible candidate only frees 1 CPU, leaving a
+			// strictly positive deficit against the 5 CPU request.
+			ceiling: "4",
+			admitted: []*workload.Info{
+				admittedWorkload("low", 1, "1"),
+			},
+			pending:     pendingWorkload("high", 10, "5"),
+			wantPop:     false,
+			wantVictims: nil,
+		},
+		"LowerOrEqualPriority policy allows preempting an equal priority workload": {
+			ceiling: "2",
+			admitted: []*workload.Info{
+				admittedWorkload("sibling", 10, "2"),
+			},
+			policy:      kueue.PreemptionLowerOrEqualPriority,
+			pending:     pendingWorkload("high", 10, "2"),
+			wantPop:     true,
+			wantVictims: []string{"sibling"},
+		},
+		"avoids self-preemption among equal priority": {
+			ceiling: "2",
+			admitted: []*workload.Info{
+				admittedWorkload("sibling", 10, "2"),
+			},
+			pending:     pendingWorkload("high", 10, "2"),
+			wantPop:     false,
+			wantVictims: nil,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var cq *ClusterQueue
+			if tc.policy != "" {
+				cq = newTestClusterQueue(tc.ceiling, tc.policy)
+			} else {
+				cq = newTestClusterQueue(tc.ceiling)
+			}
+			for _, a := range tc.admitted {
+				cq.AddAdmitted(a)
+			}
+			cq.PushIfNotPresent(tc.pending)
+
+			gotW, gotVictims := cq.PopWithPreemption()
+
+			if tc.wantPop != (gotW != nil) {
+				t.Fatalf("PopWithPreemption() popped = %v, want %v", gotW != nil, tc.wantPop)
+			}
+			var gotNames []string
+			for _, v := range gotVictims {
+				gotNames = append(gotNames, v.Obj.Name)
+			}
+			if diff := cmp.Diff(tc.wantVictims, gotNames, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Unexpected victims (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func admittedWorkloadAt(name string, priority int32, cpu string, secondsAgo int64) *workload.Info {
+	w := admittedWorkload(name, priority, cpu)
+	t := metav1.NewTime(metav1.Unix(1000-secondsAgo, 0).Time)
+	w.Obj.Status.Conditions[0].LastTransitionTime = t
+	return w
+}
@@ -19,6 +19,7 @@ package queue
 import (
 	"container/heap"
 	"fmt"
+	"sort"
 
 	kueue "sigs.k8s.io/kueue/api/v1alpha1"
 	utilpriority "sigs.k8s.io/kueue/pkg/util/priority"
@@ -72,13 +73,25 @@ type ClusterQueue struct {
 	QueueingStrategy kueue.QueueingStrategy
 
 	heap heapImpl
+
+	// admitted holds the workloads this ClusterQueue has admitted, keyed by
+	// workload.Key. They are the preemption candidates for PopWithPreemption.
+	admitted map[string]*workload.Info
+	// capacity is the total ceiling per resource, summed across flavors.
+	capacity workload.Requests
+	// preemptionPolicy governs whether PopWithPreemption may return victims.
+	preemptionPolicy kueue.PreemptionPolicy
 }
 
 func newClusterQueue(cq *kueue.ClusterQueue) (*ClusterQueue, error) {
 	var less lessFunc
 
 	switch cq.Spec.QueueingStrategy {
-	case kueue.StrictFIFO:
+	case kueue.StrictFIFO, kueue.BestEffortFIFO:
+		// Both strategies order the heap the same way. StrictFIFO only ever
+		// consumes the head through Pop, while BestEffortFIFO additionally
+		// allows PopWhere to skip over workloads that can't be admitted yet
+		// without reordering the rest of the queue.
 		less = strictFIFO
 	default:
 		return nil, fmt.Errorf("invalid QueueingStrategy %q", cq.Spec.QueueingStrategy)
@@ -89,6 +102,7 @@ func newClusterQueue(cq *kueue.ClusterQueue) (*ClusterQueue, error) {
 			less:  less,
 			items: make(map[string]*heapItem),
 		},
+		admitted: make(map[string]*workload.Info),
 	}
 	cqImpl.update(cq)
 	return cqImpl, nil
@@ -96,6 +110,16 @@ func newClusterQueue(cq *kueue.ClusterQueue) (*ClusterQueue, error) {
 
 func (cq *ClusterQueue) update(apiCQ *kueue.ClusterQueue) {
 	cq.QueueingStrategy = apiCQ.Spec.QueueingStrategy
+	cq.preemptionPolicy = apiCQ.Spec.PreemptionPolicy
+	capacity := make(workload.Requests, len(apiCQ.Spec.Resources))
+	for _, r := range apiCQ.Spec.Resources {
+		var total int64
+		for _, f := range r.Flavors {
+			total += workload.ResourceValue(r.Name, f.Ceiling)
+		}
+		capacity[r.Name] = total
+	}
+	cq.capacity = capacity
 }
 
 func (cq *ClusterQueue) AddFromQueue(q *Queue) bool {
@@ -149,6 +173,46 @@ func (cq *ClusterQueue) Pop() *workload.Info {
 	return &w
 }
 
+// Snapshot returns the workloads currently in the queue, ordered the same
+// way Pop would return them. It doesn't mutate the queue, so it's safe to
+// use for BestEffortFIFO ClusterQueues where the scheduler needs to look
+// past the head of the queue without losing its place.
+func (cq *ClusterQueue) Snapshot() []workload.Info {
+	items := make([]workload.Info, 0, cq.heap.Len())
+	for _, key := range cq.heap.heap {
+		items = append(items, cq.heap.items[key].obj)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return cq.heap.less(items[i], items[j])
+	})
+	return items
+}
+
+// PopWhere removes and returns the first workload in heap order that
+// satisfies match, leaving the relative order of the remaining workloads
+// untouched. It returns nil if no workload matches.
+//
+// This is what lets a BestEffortFIFO ClusterQueue skip a head-of-line
+// workload that doesn't currently fit without subjecting the rest of the
+// queue to priority inversion: workloads that are examined and rejected are
+// pushed back onto the heap before PopWhere returns.
+func (cq *ClusterQueue) PopWhere(match func(*workload.Info) bool) *workload.Info {
+	var skipped []workload.Info
+	var result *workload.Info
+	for cq.heap.Len() > 0 {
+		w := heap.Pop(&cq.heap).(workload.Info)
+		if match(&w) {
+			result = &w
+			break
+		}
+		skipped = append(skipped, w)
+	}
+	for i := range skipped {
+		heap.Push(&cq.heap, skipped[i])
+	}
+	return result
+}
+
 // strictFIFO is the function used by the clusterQueue heap algorithm to sort
 // workloads. It sorts workloads based on their priority.
 // When priorities are equal, it uses workloads.creationTimestamp.
@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kueue "sigs.k8s.io/kueue/api/v1alpha1"
+	"sigs.k8s.io/kueue/pkg/workload"
+)
+
+func TestNewClusterQueueBestEffortFIFO(t *testing.T) {
+	if _, err := newClusterQueue(&kueue.ClusterQueue{
+		ObjectMeta: metav1.ObjectMeta{Name: "cq"},
+		Spec:       kueue.ClusterQueueSpec{QueueingStrategy: kueue.BestEffortFIFO},
+	}); err != nil {
+		t.Fatalf("newClusterQueue() error = %v, want nil", err)
+	}
+}
+
+func TestClusterQueuePopWhere(t *testing.T) {
+	cq := newTestClusterQueue("10")
+	a := pendingWorkload("a", 10, "1")
+	b := pendingWorkload("b", 5, "1")
+	c := pendingWorkload("c", 1, "1")
+	cq.PushIfNotPresent(a)
+	cq.PushIfNotPresent(b)
+	cq.PushIfNotPresent(c)
+
+	// Skip past the head ("a") and admit "b" instead.
+	got := cq.PopWhere(func(info *workload.Info) bool {
+		return info.Obj.Name == "b"
+	})
+	if got == nil || got.Obj.Name != "b" {
+		t.Fatalf("PopWhere() = %v, want b", got)
+	}
+
+	// "a" and "c" must still be in the heap, in their original order, so a
+	// later Pop() doesn't suffer priority inversion.
+	if diff := cmp.Diff([]string{"a", "c"}, snapshotNames(cq)); diff != "" {
+		t.Errorf("Unexpected remaining order after PopWhere (-want,+got):\n%s", diff)
+	}
+	if next := cq.Pop(); next == nil || next.Obj.Name != "a" {
+		t.Fatalf("Pop() = %v, want a", next)
+	}
+}
+
+func TestClusterQueuePopWhereNoMatch(t *testing.T) {
+	cq := newTestClusterQueue("10")
+	cq.PushIfNotPresent(pendingWorkload("a", 10, "1"))
+
+	if got := cq.PopWhere(func(info *workload.Info) bool { return false }); got != nil {
+		t.Fatalf("PopWhere() = %v, want nil", got)
+	}
+	if diff := cmp.Diff([]string{"a"}, snapshotNames(cq)); diff != "" {
+		t.Errorf("Workload wasn't requeued after a non-matching PopWhere (-want,+got):\n%s", diff)
+	}
+}
+
+func snapshotNames(cq *ClusterQueue) []string {
+	var names []string
+	for _, info := range cq.Snapshot() {
+		names = append(names, info.Obj.Name)
+	}
+	return names
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kueue "sigs.k8s.io/kueue/api/v1alpha1"
+)
+
+// ActiveDeadline sets Spec.ActiveDeadlineSeconds on the wrapped workload.
+func (w *QueuedWorkloadWrapper) ActiveDeadline(d time.Duration) *QueuedWorkloadWrapper {
+	s := int64(d.Seconds())
+	w.Spec.ActiveDeadlineSeconds = &s
+	return w
+}
+
+// AdmittedAt sets the QueuedWorkloadAdmitted condition's LastTransitionTime,
+// overwriting it if already present.
+func (w *QueuedWorkloadWrapper) AdmittedAt(t time.Time) *QueuedWorkloadWrapper {
+	cond := kueue.QueuedWorkloadCondition{
+		Type:               kueue.QueuedWorkloadAdmitted,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(t),
+	}
+	for i, c := range w.Status.Conditions {
+		if c.Type == kueue.QueuedWorkloadAdmitted {
+			w.Status.Conditions[i] = cond
+			return w
+		}
+	}
+	w.Status.Conditions = append(w.Status.Conditions, cond)
+	return w
+}
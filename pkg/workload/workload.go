@@ -19,9 +19,11 @@ package workload
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	kueue "sigs.k8s.io/kueue/api/v1alpha1"
 )
@@ -39,12 +41,87 @@ type PodSetResources struct {
 	Name     string
 	Requests Requests
 	Flavors  map[corev1.ResourceName]string
+	// DeviceRequests records the per-podSet count of extended/scalar
+	// resources (e.g. nvidia.com/gpu, as recognized by
+	// IsExtendedResourceName; hugepages-* is not included) along with any
+	// topology hint requested for them, so the flavor assignment path can
+	// keep devices with the same hint together.
+	DeviceRequests []DeviceRequest
+}
+
+// DeviceRequest is the amount of an extended resource requested by a
+// podSet, plus an optional topology hint parsed from the workload's
+// topologyAnnotationKey annotation for that resource (e.g. "socket", "node").
+type DeviceRequest struct {
+	Name        corev1.ResourceName
+	Count       int64
+	TopologyKey string
+}
+
+// topologyAnnotationPrefix marks annotations that pin an extended resource
+// to a topology granularity. The resource name is appended with any "/" it
+// contains replaced by "_" (e.g. "nvidia.com/gpu" becomes
+// "kueue.x-k8s.io/topology-nvidia.com_gpu"): a Kubernetes annotation key
+// allows at most one "/", so the resource name can't be embedded verbatim
+// without producing an invalid key.
+const topologyAnnotationPrefix = "kueue.x-k8s.io/topology-"
+
+// topologyAnnotationKey returns the annotation key that carries the
+// topology hint for the extended resource name.
+func topologyAnnotationKey(name corev1.ResourceName) string {
+	return topologyAnnotationPrefix + strings.ReplaceAll(string(name), "/", "_")
+}
+
+// IsExtendedResourceName reports whether name is a vendor or device
+// resource, such as nvidia.com/gpu, as opposed to a built-in resource like
+// cpu, memory or hugepages-*, which are tracked like native resources.
+func IsExtendedResourceName(name corev1.ResourceName) bool {
+	switch name {
+	case corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage, corev1.ResourcePods:
+		return false
+	}
+	if strings.HasPrefix(string(name), corev1.ResourceHugePagesPrefix) {
+		return false
+	}
+	if !strings.Contains(string(name), "/") {
+		return false
+	}
+	return !strings.HasPrefix(string(name), "kubernetes.io/")
+}
+
+// TopologyConsistent reports whether every topology hint in reqs maps to a
+// single flavor per podSet: if two extended resources share a topology key
+// (e.g. both are pinned to the same socket) but end up assigned to
+// different flavors, the hint can't be honored. It's meant to be checked
+// once a workload's podSets have candidate flavors, i.e. as part of flavor
+// assignment; this package has no such step yet, so nothing calls this.
+func TopologyConsistent(reqs []PodSetResources) bool {
+	for _, ps := range reqs {
+		if len(ps.Flavors) == 0 {
+			continue
+		}
+		flavorByKey := make(map[string]string)
+		for _, d := range ps.DeviceRequests {
+			if d.TopologyKey == "" {
+				continue
+			}
+			flavor, ok := ps.Flavors[d.Name]
+			if !ok {
+				continue
+			}
+			if existing, seen := flavorByKey[d.TopologyKey]; seen && existing != flavor {
+				return false
+			}
+			flavorByKey[d.TopologyKey] = flavor
+		}
+	}
+	return true
 }
 
 func NewInfo(w *kueue.QueuedWorkload) *Info {
 	return &Info{
 		Obj:           w,
-		TotalRequests: totalRequests(&w.Spec),
+		TotalRequests: totalRequests(w),
 	}
 }
 
@@ -52,7 +129,8 @@ func Key(w *kueue.QueuedWorkload) string {
 	return fmt.Sprintf("%s/%s", w.Namespace, w.Name)
 }
 
-func totalRequests(spec *kueue.QueuedWorkloadSpec) []PodSetResources {
+func totalRequests(w *kueue.QueuedWorkload) []PodSetResources {
+	spec := &w.Spec
 	if len(spec.PodSets) == 0 {
 		return nil
 	}
@@ -70,6 +148,7 @@ func totalRequests(spec *kueue.QueuedWorkloadSpec) []PodSetResources {
 		}
 		setRes.Requests = podRequests(&ps.Spec)
 		setRes.Requests.scale(int64(ps.Count))
+		setRes.DeviceRequests = deviceRequests(setRes.Requests, w.Annotations)
 		flavors := podSetFlavors[ps.Name]
 		if len(flavors) > 0 {
 			setRes.Flavors = make(map[corev1.ResourceName]string, len(flavors))
@@ -82,6 +161,23 @@ func totalRequests(spec *kueue.QueuedWorkloadSpec) []PodSetResources {
 	return res
 }
 
+// deviceRequests picks out the extended resources in reqs and attaches any
+// topology hint declared for them in annotations.
+func deviceRequests(reqs Requests, annotations map[string]string) []DeviceRequest {
+	var devices []DeviceRequest
+	for name, val := range reqs {
+		if !IsExtendedResourceName(name) {
+			continue
+		}
+		d := DeviceRequest{Name: name, Count: val}
+		if key, ok := annotations[topologyAnnotationKey(name)]; ok {
+			d.TopologyKey = key
+		}
+		devices = append(devices, d)
+	}
+	return devices
+}
+
 // The following resources calculations are inspired on
 // https://github.com/kubernetes/kubernetes/blob/master/pkg/scheduler/framework/types.go
 
@@ -131,6 +227,109 @@ func ResourceQuantity(name corev1.ResourceName, v int64) resource.Quantity {
 	}
 }
 
+// AdmittedAt returns the time recorded in the QueuedWorkloadAdmitted
+// condition, and whether that condition is present. A workload without the
+// condition hasn't been admitted yet, or was admitted before this condition
+// was introduced.
+func (i *Info) AdmittedAt() (metav1.Time, bool) {
+	for _, c := range i.Obj.Status.Conditions {
+		if c.Type == kueue.QueuedWorkloadAdmitted {
+			return c.LastTransitionTime, true
+		}
+	}
+	return metav1.Time{}, false
+}
+
+// DeadlineAt returns the time at which i must be evicted for exceeding its
+// runtime, and whether such a deadline applies. The deadline is the earlier
+// of the workload's own Spec.ActiveDeadlineSeconds and cq's
+// Spec.MaxRunDuration, counted from the workload's admission time. It
+// returns false if the workload isn't admitted or neither limit is set.
+func (i *Info) DeadlineAt(cq *kueue.ClusterQueue) (metav1.Time, bool) {
+	admittedAt, ok := i.AdmittedAt()
+	if !ok {
+		return metav1.Time{}, false
+	}
+	var runtime *time.Duration
+	if s := i.Obj.Spec.ActiveDeadlineSeconds; s != nil {
+		d := time.Duration(*s) * time.Second
+		runtime = &d
+	}
+	if d := cq.Spec.MaxRunDuration; d != nil && (runtime == nil || d.Duration < *runtime) {
+		runtime = &d.Duration
+	}
+	if runtime == nil {
+		return metav1.Time{}, false
+	}
+	return metav1.NewTime(admittedAt.Add(*runtime)), true
+}
+
+// FlavorUsage maps a resource name to a flavor name to the total quantity
+// currently in use by admitted workloads across a whole ClusterQueue.
+type FlavorUsage map[corev1.ResourceName]map[string]int64
+
+// AggregateFlavorUsage sums the per-flavor resource usage of every workload
+// in infos. Callers pass in the full set of workloads currently admitted by
+// a ClusterQueue to get that ClusterQueue's actual in-use amount per flavor,
+// which is what drift detection has to compare a lowered ceiling against.
+func AggregateFlavorUsage(infos []*Info) FlavorUsage {
+	usage := make(FlavorUsage)
+	for _, info := range infos {
+		for _, ps := range info.TotalRequests {
+			for resName, flavor := range ps.Flavors {
+				if usage[resName] == nil {
+					usage[resName] = make(map[string]int64)
+				}
+				usage[resName][flavor] += ps.Requests[resName]
+			}
+		}
+	}
+	return usage
+}
+
+// Drifted reports whether an admitted workload no longer fits the current
+// spec of its ClusterQueue, e.g. because a flavor it was assigned was
+// removed, or its ceiling was lowered below the ClusterQueue's actual
+// in-use amount, given in usage.
+func (i *Info) Drifted(cq *kueue.ClusterQueue, usage FlavorUsage) bool {
+	return i.Obj.Spec.Admission != nil && !i.flavorsStillValid(cq, usage)
+}
+
+// flavorsStillValid reports whether the flavors this workload was admitted
+// with are still defined in cq and still have enough ceiling to cover the
+// ClusterQueue's aggregate in-use amount for them, as recorded in usage.
+// It's used to detect drift after a ClusterQueue's resources, flavors or
+// quotas are edited out from under an admitted workload.
+func (i *Info) flavorsStillValid(cq *kueue.ClusterQueue, usage FlavorUsage) bool {
+	if i.Obj.Spec.Admission == nil {
+		return true
+	}
+	ceilings := make(map[corev1.ResourceName]map[string]resource.Quantity)
+	for _, r := range cq.Spec.Resources {
+		flavors := make(map[string]resource.Quantity, len(r.Flavors))
+		for _, f := range r.Flavors {
+			flavors[f.Name] = f.Ceiling
+		}
+		ceilings[r.Name] = flavors
+	}
+	for _, ps := range i.TotalRequests {
+		for resName, flavor := range ps.Flavors {
+			flavors, ok := ceilings[resName]
+			if !ok {
+				return false
+			}
+			ceiling, ok := flavors[flavor]
+			if !ok {
+				return false
+			}
+			if ResourceValue(resName, ceiling) < usage[resName][flavor] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 func (r Requests) add(o Requests) {
 	for name, val := range o {
 		r[name] += val
@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workload
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestIsExtendedResourceName(t *testing.T) {
+	cases := map[corev1.ResourceName]bool{
+		corev1.ResourceCPU:      false,
+		corev1.ResourceMemory:   false,
+		"hugepages-2Mi":         false,
+		"kubernetes.io/battery": false,
+		"nvidia.com/gpu":        true,
+		"example.com/fpga":      true,
+	}
+	for name, want := range cases {
+		if got := IsExtendedResourceName(name); got != want {
+			t.Errorf("IsExtendedResourceName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestTopologyAnnotationKey(t *testing.T) {
+	cases := map[corev1.ResourceName]string{
+		"nvidia.com/gpu":   "kueue.x-k8s.io/topology-nvidia.com_gpu",
+		"example.com/fpga": "kueue.x-k8s.io/topology-example.com_fpga",
+	}
+	for name, want := range cases {
+		got := topologyAnnotationKey(name)
+		if got != want {
+			t.Errorf("topologyAnnotationKey(%q) = %q, want %q", name, got, want)
+		}
+		if n := strings.Count(got, "/"); n > 1 {
+			t.Errorf("topologyAnnotationKey(%q) = %q has %d '/', want at most 1", name, got, n)
+		}
+	}
+}
+
+func TestTopologyConsistent(t *testing.T) {
+	cases := map[string]struct {
+		reqs []PodSetResources
+		want bool
+	}{
+		"no topology hints": {
+			reqs: []PodSetResources{{
+				Flavors: map[corev1.ResourceName]string{"nvidia.com/gpu": "a"},
+				DeviceRequests: []DeviceRequest{
+					{Name: "nvidia.com/gpu", Count: 1},
+				},
+			}},
+			want: true,
+		},
+		"devices sharing a topology key land on the same flavor": {
+			reqs: []PodSetResources{{
+				Flavors: map[corev1.ResourceName]string{
+					"nvidia.com/gpu": "a",
+					"vendor.com/nic": "a",
+				},
+				DeviceRequests: []DeviceRequest{
+					{Name: "nvidia.com/gpu", Count: 1, TopologyKey: "socket"},
+					{Name: "vendor.com/nic", Count: 1, TopologyKey: "socket"},
+				},
+			}},
+			want: true,
+		},
+		"devices sharing a topology key land on different flavors": {
+			reqs: []PodSetResources{{
+				Flavors: map[corev1.ResourceName]string{
+					"nvidia.com/gpu": "a",
+					"vendor.com/nic": "b",
+				},
+				DeviceRequests: []DeviceRequest{
+					{Name: "nvidia.com/gpu", Count: 1, TopologyKey: "socket"},
+					{Name: "vendor.com/nic", Count: 1, TopologyKey: "socket"},
+				},
+			}},
+			want: false,
+		},
+		"not yet assigned a flavor": {
+			reqs: []PodSetResources{{
+				DeviceRequests: []DeviceRequest{
+					{Name: "nvidia.com/gpu", Count: 1, TopologyKey: "socket"},
+					{Name: "vendor.com/nic", Count: 1, TopologyKey: "socket"},
+				},
+			}},
+			want: true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := TopologyConsistent(tc.reqs); got != tc.want {
+				t.Errorf("TopologyConsistent() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}